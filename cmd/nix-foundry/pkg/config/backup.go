@@ -0,0 +1,453 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const backupIndexFilename = "index.yaml"
+
+// BackupEntry describes one archive in the BackupStore's index.
+type BackupEntry struct {
+	ID        string    `yaml:"id"`
+	Path      string    `yaml:"path"`
+	Timestamp time.Time `yaml:"timestamp"`
+	Hash      string    `yaml:"hash"`
+	Command   string    `yaml:"command,omitempty"`
+	Label     string    `yaml:"label,omitempty"`
+}
+
+type backupIndex struct {
+	Backups []BackupEntry `yaml:"backups"`
+}
+
+// PrunePolicy describes which backups PruneBackups is allowed to delete.
+// A zero-value field disables that rule.
+type PrunePolicy struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	MaxAgeDays  int
+}
+
+// CreateBackup creates a timestamped backup of the current configuration.
+// It's a thin wrapper around CreateLabeledBackup for callers (Apply, SafeWrite)
+// that don't need the resulting BackupEntry or a specific label.
+func (cm *Manager) CreateBackup() error {
+	_, err := cm.CreateLabeledBackup("", "")
+	return err
+}
+
+// CreateLabeledBackup archives configDir (excluding the backup store
+// itself) as a gzipped tarball under backupDir, records it in
+// backups/index.yaml alongside its sha256 hash, triggering command, and
+// label, and returns the resulting entry.
+func (cm *Manager) CreateLabeledBackup(label, command string) (BackupEntry, error) {
+	if err := os.MkdirAll(cm.backupDir, 0755); err != nil {
+		return BackupEntry{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	timestamp := time.Now()
+	id := timestamp.Format("20060102-150405")
+	filename := fmt.Sprintf("backup-%s.tar.gz", id)
+	archivePath := filepath.Join(cm.backupDir, filename)
+
+	hash, err := cm.writeBackupArchive(archivePath)
+	if err != nil {
+		return BackupEntry{}, err
+	}
+
+	entry := BackupEntry{
+		ID:        id,
+		Path:      filename,
+		Timestamp: timestamp,
+		Hash:      hash,
+		Command:   command,
+		Label:     label,
+	}
+
+	idx, err := cm.loadBackupIndex()
+	if err != nil {
+		return BackupEntry{}, err
+	}
+	idx.Backups = append(idx.Backups, entry)
+	if err := cm.saveBackupIndex(idx); err != nil {
+		return BackupEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// writeBackupArchive tars and gzips configDir into archivePath using the
+// stdlib (so backups work without a `tar` binary on PATH), hashing the
+// tarball contents as they're written rather than in a second pass.
+func (cm *Manager) writeBackupArchive(archivePath string) (string, error) {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup archive: %w", err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	gw := gzip.NewWriter(io.MultiWriter(f, hasher))
+	tw := tar.NewWriter(gw)
+
+	walkErr := filepath.Walk(cm.configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == cm.backupDir {
+			return filepath.SkipDir
+		}
+
+		relPath, err := filepath.Rel(cm.configDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		_, err = io.Copy(tw, src)
+		return err
+	})
+
+	if closeErr := tw.Close(); walkErr == nil {
+		walkErr = closeErr
+	}
+	if closeErr := gw.Close(); walkErr == nil {
+		walkErr = closeErr
+	}
+	if walkErr != nil {
+		return "", fmt.Errorf("failed to create backup archive: %w", walkErr)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ListBackups returns every backup in the index, most recent first.
+func (cm *Manager) ListBackups() ([]BackupEntry, error) {
+	idx, err := cm.loadBackupIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := idx.Backups
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup takes a safety backup (unless opts.Backup is false), then
+// atomically swaps configDir for the contents of backup id and re-runs
+// home-manager switch. The backup store itself is preserved across the
+// swap so restoring doesn't erase backup history.
+func (cm *Manager) RestoreBackup(id string, opts Options) error {
+	idx, err := cm.loadBackupIndex()
+	if err != nil {
+		return err
+	}
+
+	var entry *BackupEntry
+	for i := range idx.Backups {
+		if idx.Backups[i].ID == id {
+			entry = &idx.Backups[i]
+			break
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("backup %s not found", id)
+	}
+
+	if opts.Backup {
+		if _, err := cm.CreateLabeledBackup("pre-restore", "restore "+id); err != nil {
+			return fmt.Errorf("safety backup failed: %w", err)
+		}
+	}
+
+	tmpDir := cm.configDir + ".restore-tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return fmt.Errorf("failed to clear restore staging directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := copyDir(cm.backupDir, filepath.Join(tmpDir, filepath.Base(cm.backupDir))); err != nil {
+		return fmt.Errorf("failed to preserve backup store: %w", err)
+	}
+
+	if err := extractTarGz(filepath.Join(cm.backupDir, entry.Path), tmpDir); err != nil {
+		return fmt.Errorf("failed to extract backup %s: %w", id, err)
+	}
+
+	oldDir := cm.configDir + ".restore-old"
+	if err := os.RemoveAll(oldDir); err != nil {
+		return fmt.Errorf("failed to clear previous-config staging directory: %w", err)
+	}
+
+	if err := os.Rename(cm.configDir, oldDir); err != nil {
+		return fmt.Errorf("failed to stage current config aside: %w", err)
+	}
+
+	if err := os.Rename(tmpDir, cm.configDir); err != nil {
+		if rollbackErr := os.Rename(oldDir, cm.configDir); rollbackErr != nil {
+			return fmt.Errorf("failed to swap in restored config and failed to roll back: %w (original error: %v)", rollbackErr, err)
+		}
+		return fmt.Errorf("failed to swap in restored config, rolled back: %w", err)
+	}
+	os.RemoveAll(oldDir)
+
+	cmd := exec.Command("home-manager", "switch")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to apply restored configuration: %w", err)
+	}
+
+	return nil
+}
+
+// PruneBackups deletes archives not retained by policy. KeepLast keeps the
+// N most recent backups outright; KeepDaily/Weekly/Monthly keep the newest
+// backup in each of the N most recent calendar days/ISO weeks/months that
+// have a backup; MaxAgeDays, when set, deletes anything older regardless of
+// the rules above. With no Keep* rule set, every backup MaxAgeDays does not
+// delete is kept.
+func (cm *Manager) PruneBackups(policy PrunePolicy) error {
+	if policy.KeepLast <= 0 && policy.KeepDaily <= 0 && policy.KeepWeekly <= 0 && policy.KeepMonthly <= 0 && policy.MaxAgeDays <= 0 {
+		return fmt.Errorf("prune policy has no retention rule set, refusing to delete every backup")
+	}
+
+	idx, err := cm.loadBackupIndex()
+	if err != nil {
+		return err
+	}
+
+	backups := append([]BackupEntry(nil), idx.Backups...)
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+
+	keep := make(map[string]bool, len(backups))
+	keepRuleSet := policy.KeepLast > 0 || policy.KeepDaily > 0 || policy.KeepWeekly > 0 || policy.KeepMonthly > 0
+
+	for i, b := range backups {
+		if !keepRuleSet || (policy.KeepLast > 0 && i < policy.KeepLast) {
+			keep[b.ID] = true
+		}
+	}
+
+	keepNewestPerBucket(backups, policy.KeepDaily, keep, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepNewestPerBucket(backups, policy.KeepWeekly, keep, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepNewestPerBucket(backups, policy.KeepMonthly, keep, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	if policy.MaxAgeDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -policy.MaxAgeDays)
+		for _, b := range backups {
+			if b.Timestamp.Before(cutoff) {
+				delete(keep, b.ID)
+			}
+		}
+	}
+
+	var remaining []BackupEntry
+	for _, b := range backups {
+		if keep[b.ID] {
+			remaining = append(remaining, b)
+			continue
+		}
+		if err := os.Remove(filepath.Join(cm.backupDir, b.Path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete backup %s: %w", b.ID, err)
+		}
+	}
+
+	idx.Backups = remaining
+	return cm.saveBackupIndex(idx)
+}
+
+// keepNewestPerBucket marks the newest backup in each of the first
+// maxBuckets distinct buckets (as produced by bucketOf) for retention.
+// backups must be sorted newest-first.
+func keepNewestPerBucket(backups []BackupEntry, maxBuckets int, keep map[string]bool, bucketOf func(time.Time) string) {
+	if maxBuckets <= 0 {
+		return
+	}
+
+	seen := make(map[string]bool, maxBuckets)
+	for _, b := range backups {
+		bucket := bucketOf(b.Timestamp)
+		if seen[bucket] {
+			continue
+		}
+		if len(seen) >= maxBuckets {
+			break
+		}
+		seen[bucket] = true
+		keep[b.ID] = true
+	}
+}
+
+func (cm *Manager) loadBackupIndex() (*backupIndex, error) {
+	data, err := os.ReadFile(filepath.Join(cm.backupDir, backupIndexFilename))
+	if os.IsNotExist(err) {
+		return &backupIndex{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read backup index: %w", err)
+	}
+
+	var idx backupIndex
+	if err := yaml.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("invalid backup index format: %w", err)
+	}
+
+	return &idx, nil
+}
+
+func (cm *Manager) saveBackupIndex(idx *backupIndex) error {
+	if err := os.MkdirAll(cm.backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup index: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(cm.backupDir, backupIndexFilename), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup index: %w", err)
+	}
+
+	return nil
+}
+
+// extractTarGz extracts a gzipped tarball created by writeBackupArchive
+// into destDir.
+func extractTarGz(archivePath, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid archive entry path: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+	}
+}
+
+// copyDir recursively copies srcDir into destDir.
+func copyDir(srcDir, destDir string) error {
+	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, relPath)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, src)
+		return err
+	})
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestBackupManager(t *testing.T) *Manager {
+	t.Helper()
+
+	dir := t.TempDir()
+	return &Manager{
+		configDir: filepath.Join(dir, "config"),
+		backupDir: filepath.Join(dir, "backups"),
+		logger:    NewLogger(false),
+	}
+}
+
+func daysAgo(n int) time.Time {
+	return time.Now().AddDate(0, 0, -n)
+}
+
+func TestPruneBackupsRejectsZeroValuePolicy(t *testing.T) {
+	cm := newTestBackupManager(t)
+
+	idx := backupIndex{Backups: []BackupEntry{
+		{ID: "a", Path: "a.tar.gz", Timestamp: daysAgo(1)},
+	}}
+	if err := cm.saveBackupIndex(&idx); err != nil {
+		t.Fatalf("saveBackupIndex() error = %v", err)
+	}
+
+	if err := cm.PruneBackups(PrunePolicy{}); err == nil {
+		t.Fatal("PruneBackups() error = nil, want an error for a zero-value policy")
+	}
+
+	after, err := cm.loadBackupIndex()
+	if err != nil {
+		t.Fatalf("loadBackupIndex() error = %v", err)
+	}
+	if len(after.Backups) != 1 {
+		t.Fatalf("backups after rejected prune = %d, want 1 (untouched)", len(after.Backups))
+	}
+}
+
+func TestKeepNewestPerBucketKeepsOneEntryPerDay(t *testing.T) {
+	// A fixed noon reference keeps "an hour earlier" on the same calendar day.
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.Local)
+	backups := []BackupEntry{
+		{ID: "today-2", Timestamp: now},
+		{ID: "today-1", Timestamp: now.Add(-time.Hour)},
+		{ID: "yesterday", Timestamp: now.AddDate(0, 0, -1)},
+		{ID: "last-week", Timestamp: now.AddDate(0, 0, -8)},
+	}
+
+	keep := make(map[string]bool)
+	keepNewestPerBucket(backups, 2, keep, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+
+	if !keep["today-2"] {
+		t.Error("expected the newest entry from today to be kept")
+	}
+	if keep["today-1"] {
+		t.Error("expected the older same-day entry to be dropped")
+	}
+	if !keep["yesterday"] {
+		t.Error("expected yesterday's entry to be kept (2nd bucket)")
+	}
+	if keep["last-week"] {
+		t.Error("expected a 3rd bucket to be dropped when maxBuckets is 2")
+	}
+}
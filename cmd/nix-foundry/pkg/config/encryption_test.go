@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"filippo.io/age"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestAgeBackend(t *testing.T) *ageBackend {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+
+	return &ageBackend{
+		identity:   identity,
+		recipients: []age.Recipient{identity.Recipient()},
+	}
+}
+
+func TestDecryptTreeEncryptTreeRoundTrip(t *testing.T) {
+	backend := newTestAgeBackend(t)
+
+	ciphertext, err := backend.Encrypt([]byte("hunter2"), "")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	passwordNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: encryptedTag, Value: base64.StdEncoding.EncodeToString(ciphertext)}
+	settingsNode := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "password"}, passwordNode,
+		},
+	}
+	teamNode := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "settings"}, settingsNode,
+			{Kind: yaml.ScalarNode, Value: "name"}, {Kind: yaml.ScalarNode, Value: "infra"},
+		},
+	}
+	root := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "team"}, teamNode,
+		},
+	}
+	doc := yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+
+	encrypted, err := decryptTree(&doc, backend)
+	if err != nil {
+		t.Fatalf("decryptTree() error = %v", err)
+	}
+
+	want := "team.settings.password"
+	if len(encrypted) != 1 || encrypted[0] != want {
+		t.Fatalf("decryptTree() encrypted paths = %v, want [%s]", encrypted, want)
+	}
+
+	var decoded struct {
+		Team struct {
+			Settings struct {
+				Password string `yaml:"password"`
+			} `yaml:"settings"`
+		} `yaml:"team"`
+	}
+	if err := doc.Decode(&decoded); err != nil {
+		t.Fatalf("doc.Decode() error = %v", err)
+	}
+	if decoded.Team.Settings.Password != "hunter2" {
+		t.Fatalf("decrypted password = %q, want %q", decoded.Team.Settings.Password, "hunter2")
+	}
+
+	encryptedPaths := map[string]bool{want: true}
+	if err := encryptTree(&doc, backend, encryptedPaths); err != nil {
+		t.Fatalf("encryptTree() error = %v", err)
+	}
+
+	redecrypted, err := decryptTree(&doc, backend)
+	if err != nil {
+		t.Fatalf("decryptTree() after re-encrypt error = %v", err)
+	}
+	if len(redecrypted) != 1 || redecrypted[0] != want {
+		t.Fatalf("decryptTree() after re-encrypt paths = %v, want [%s]", redecrypted, want)
+	}
+
+	var roundTripped struct {
+		Team struct {
+			Settings struct {
+				Password string `yaml:"password"`
+			} `yaml:"settings"`
+		} `yaml:"team"`
+	}
+	if err := doc.Decode(&roundTripped); err != nil {
+		t.Fatalf("doc.Decode() after re-encrypt error = %v", err)
+	}
+	if roundTripped.Team.Settings.Password != "hunter2" {
+		t.Fatalf("round-tripped password = %q, want %q", roundTripped.Team.Settings.Password, "hunter2")
+	}
+}
+
+func TestEncryptTreeSkipsPathsNotMarkedEncrypted(t *testing.T) {
+	backend := newTestAgeBackend(t)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("team:\n  name: infra\n"), &doc); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+
+	if err := encryptTree(&doc, backend, map[string]bool{"team.password": true}); err != nil {
+		t.Fatalf("encryptTree() error = %v", err)
+	}
+
+	var decoded struct {
+		Team struct {
+			Name string `yaml:"name"`
+		} `yaml:"team"`
+	}
+	if err := doc.Decode(&decoded); err != nil {
+		t.Fatalf("doc.Decode() error = %v", err)
+	}
+	if decoded.Team.Name != "infra" {
+		t.Fatalf("name = %q, want %q (should be untouched)", decoded.Team.Name, "infra")
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+//go:embed templates/home-manager/*.tmpl templates/home-manager/modules/*.tmpl
+var builtinTemplates embed.FS
+
+// homeManagerFiles lists the templates (relative to templates/home-manager)
+// that make up a home-manager output tree, and the relative path each is
+// written to under configDir/home-manager.
+var homeManagerFiles = map[string]string{
+	"home.nix.tmpl":           "home.nix",
+	"flake.nix.tmpl":          "flake.nix",
+	"modules/shell.nix.tmpl":  "modules/shell.nix",
+	"modules/editor.nix.tmpl": "modules/editor.nix",
+	"modules/git.nix.tmpl":    "modules/git.nix",
+}
+
+// NixGenerator renders a NixConfig into one or more Nix files, keyed by
+// their path relative to the generator's output directory.
+type NixGenerator interface {
+	Generate(config *NixConfig) (map[string]string, error)
+}
+
+// Generators is a registry of NixGenerator implementations keyed by output
+// kind ("home-manager", "nix-darwin", "flake"), so Manager.Apply can pick
+// the right one based on NixConfig.Platform.
+type Generators struct {
+	mu  sync.RWMutex
+	reg map[string]NixGenerator
+}
+
+// NewGenerators builds a registry pre-populated with the built-in
+// home-manager, nix-darwin, and flake generators.
+func NewGenerators(templateDir string, funcs template.FuncMap) (*Generators, error) {
+	homeManager, err := newTemplateGenerator(templateDir, homeManagerFiles, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Generators{reg: make(map[string]NixGenerator)}
+	g.Register("home-manager", homeManager)
+	// nix-darwin and standalone-flake hosts still render their home
+	// environment through home-manager; platform-specific system settings
+	// are layered on top by the Nix configuration itself.
+	g.Register("nix-darwin", homeManager)
+	g.Register("flake", homeManager)
+
+	return g, nil
+}
+
+// Register adds or replaces the generator used for kind.
+func (g *Generators) Register(kind string, gen NixGenerator) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reg[kind] = gen
+}
+
+// Get returns the generator registered for kind.
+func (g *Generators) Get(kind string) (NixGenerator, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	gen, ok := g.reg[kind]
+	if !ok {
+		return nil, fmt.Errorf("no generator registered for platform %q", kind)
+	}
+	return gen, nil
+}
+
+// templateGenerator is the default, text/template-based NixGenerator. Users
+// can override any built-in template by placing a same-named file under
+// templateDir (configDir/templates/*.nix.tmpl).
+type templateGenerator struct {
+	templateDir string
+	files       map[string]string // template name (relative to templates/home-manager) -> output path
+	funcs       template.FuncMap
+}
+
+func newTemplateGenerator(templateDir string, files map[string]string, funcs template.FuncMap) (*templateGenerator, error) {
+	return &templateGenerator{templateDir: templateDir, files: files, funcs: nixTemplateFuncs(funcs)}, nil
+}
+
+func (g *templateGenerator) Generate(config *NixConfig) (map[string]string, error) {
+	out := make(map[string]string, len(g.files))
+
+	for name, outPath := range g.files {
+		tmpl, err := g.loadTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, config); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", name, err)
+		}
+
+		out[outPath] = buf.String()
+	}
+
+	return out, nil
+}
+
+// loadTemplate prefers a user override at templateDir/<name> over the
+// built-in copy baked into the binary.
+func (g *templateGenerator) loadTemplate(name string) (*template.Template, error) {
+	overridePath := filepath.Join(g.templateDir, name)
+	if data, err := os.ReadFile(overridePath); err == nil {
+		tmpl, err := template.New(name).Funcs(g.funcs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse override template %s: %w", overridePath, err)
+		}
+		return tmpl, nil
+	}
+
+	data, err := builtinTemplates.ReadFile(path.Join("templates/home-manager", name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load built-in template %s: %w", name, err)
+	}
+
+	tmpl, err := template.New(name).Funcs(g.funcs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse built-in template %s: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+// nixTemplateFuncs merges the built-in Nix-formatting helpers with the
+// caller-supplied funcs (typically the encryption helpers).
+func nixTemplateFuncs(extra template.FuncMap) template.FuncMap {
+	funcs := template.FuncMap{
+		"nixString":     nixString,
+		"quote":         nixString,
+		"nixList":       nixList,
+		"nixAttrs":      nixAttrs,
+		"editorProgram": editorProgram,
+		"nixSystem":     nixSystem,
+	}
+	for name, fn := range extra {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// nixString renders s as a double-quoted Nix string literal, escaping
+// backslashes, double quotes, and the `${` interpolation sequence.
+func nixString(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"${", `\${`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
+
+// nixList renders items as a bracketed Nix list of quoted strings.
+func nixList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = nixString(item)
+	}
+	return "[ " + strings.Join(quoted, " ") + " ]"
+}
+
+// nixAttrs renders m as a Nix attribute set of string-valued keys.
+func nixAttrs(m map[string]string) string {
+	var b strings.Builder
+	b.WriteString("{ ")
+	for k, v := range m {
+		fmt.Fprintf(&b, "%s = %s; ", k, nixString(v))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// editorProgram maps a validated NixConfig editor type to the home-manager
+// program it enables. The validator accepts both "nvim" and "neovim", and
+// home-manager has no programs.nvim, so "nvim" maps to "neovim"; every
+// other type (including "vim") is used as the program name unchanged.
+func editorProgram(editorType string) string {
+	switch editorType {
+	case "nvim":
+		return "neovim"
+	default:
+		return editorType
+	}
+}
+
+// nixSystem maps a NixConfig.Platform value to a Nix system identifier for
+// use in flake.nix. Defaults to x86_64-linux when Platform is unset.
+func nixSystem(platform string) string {
+	switch platform {
+	case "nix-darwin", "darwin":
+		return "aarch64-darwin"
+	default:
+		return "x86_64-linux"
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import "testing"
+
+func TestNixString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "alice", `"alice"`},
+		{"quote", `say "hi"`, `"say \"hi\""`},
+		{"backslash", `C:\tools`, `"C:\\tools"`},
+		{"interpolation", "${HOME}/bin", `"\${HOME}/bin"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nixString(tt.in); got != tt.want {
+				t.Errorf("nixString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNixList(t *testing.T) {
+	got := nixList([]string{"git", "ripgrep"})
+	want := `[ "git" "ripgrep" ]`
+	if got != want {
+		t.Errorf("nixList() = %q, want %q", got, want)
+	}
+
+	if got := nixList(nil); got != "[  ]" {
+		t.Errorf("nixList(nil) = %q, want %q", got, "[  ]")
+	}
+}
+
+func TestEditorProgram(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"nvim", "neovim"},
+		{"vim", "vim"},
+		{"vscode", "vscode"},
+	}
+	for _, tt := range tests {
+		if got := editorProgram(tt.in); got != tt.want {
+			t.Errorf("editorProgram(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNixSystem(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"nix-darwin", "aarch64-darwin"},
+		{"darwin", "aarch64-darwin"},
+		{"home-manager", "x86_64-linux"},
+		{"", "x86_64-linux"},
+	}
+	for _, tt := range tests {
+		if got := nixSystem(tt.in); got != tt.want {
+			t.Errorf("nixSystem(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func newTestGenerators(t *testing.T) *Generators {
+	t.Helper()
+	g, err := NewGenerators(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewGenerators() error = %v", err)
+	}
+	return g
+}
+
+func TestTemplateGeneratorQuotesPackageNamesAndEscapesGitFields(t *testing.T) {
+	g := newTestGenerators(t)
+	gen, err := g.Get("home-manager")
+	if err != nil {
+		t.Fatalf("Get(home-manager) error = %v", err)
+	}
+
+	config := &NixConfig{
+		Version:  "1",
+		Platform: "home-manager",
+		Shell:    ShellConfig{Type: "zsh"},
+		Editor:   EditorConfig{Type: "nvim"},
+		Packages: PackagesConfig{Additional: []string{"ripgrep", `fd; rm -rf /`}},
+	}
+	config.Git.Enable = true
+	config.Git.User.Name = `Robert"); rm -rf ~`
+	config.Git.User.Email = "bob@example.com"
+
+	files, err := gen.Generate(config)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	home, ok := files["home.nix"]
+	if !ok {
+		t.Fatal("Generate() did not produce home.nix")
+	}
+	if want := `"fd; rm -rf /"`; !containsSubstring(home, want) {
+		t.Errorf("home.nix package list missing quoted entry %s; got:\n%s", want, home)
+	}
+
+	editorFile, ok := files["modules/editor.nix"]
+	if !ok {
+		t.Fatal("Generate() did not produce modules/editor.nix")
+	}
+	if want := "programs.neovim.enable = true;"; !containsSubstring(editorFile, want) {
+		t.Errorf("modules/editor.nix missing %q; got:\n%s", want, editorFile)
+	}
+
+	gitFile, ok := files["modules/git.nix"]
+	if !ok {
+		t.Fatal("Generate() did not produce modules/git.nix")
+	}
+	if want := `userName = "Robert\"); rm -rf ~";`; !containsSubstring(gitFile, want) {
+		t.Errorf("modules/git.nix did not escape user name; want substring %q, got:\n%s", want, gitFile)
+	}
+
+	flakeFile, ok := files["flake.nix"]
+	if !ok {
+		t.Fatal("Generate() did not produce flake.nix")
+	}
+	if !containsSubstring(flakeFile, "nixpkgs.legacyPackages.x86_64-linux") {
+		t.Errorf("flake.nix missing expected system string; got:\n%s", flakeFile)
+	}
+}
+
+func containsSubstring(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// NewLogger builds the slog.Logger used by default by NewConfigManager and
+// NewValidator. Verbose raises the level to Debug. When stdout is an
+// interactive TTY, long-running steps are better shown with a spinner
+// (see runWithSpinner), so logs are emitted as human-readable text on
+// stderr; otherwise they're emitted as structured JSON so they can be
+// piped into a log aggregator.
+func NewLogger(verbose bool) *slog.Logger {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if isTerminal(os.Stdout) {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+
+	return slog.New(handler)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (info.Mode() & os.ModeCharDevice) != 0
+}
+
+// WithLogger sets the logger used for lifecycle events (backup.created,
+// config.validated, nix.generated, home-manager.switch.start/end) and
+// returns cm for chaining.
+func (cm *Manager) WithLogger(logger *slog.Logger) *Manager {
+	cm.logger = logger
+	return cm
+}
+
+// WithLogger sets the logger the Validator uses to report validation
+// attempts alongside the errors it returns, and returns v for chaining.
+func (v *Validator) WithLogger(logger *slog.Logger) *Validator {
+	v.logger = logger
+	return v
+}
+
+// runWithSpinner runs fn, showing a terminal spinner labeled message while
+// it's interactive, or logging message.start/message.end with its duration
+// otherwise. Use this for steps like home-manager.switch that are worth
+// reporting the start of, since they can run long enough for a caller to
+// wonder whether they've hung.
+func runWithSpinner(logger *slog.Logger, message string, fn func() error) error {
+	start := time.Now()
+
+	if isTerminal(os.Stdout) {
+		sp := newSpinner(message)
+		err := fn()
+		sp.stop(err == nil)
+		return err
+	}
+
+	logger.Info(message+".start", "message", message)
+	err := fn()
+	duration := time.Since(start)
+	if err != nil {
+		logger.Error(message+".end", "message", message, "duration", duration, "error", err)
+	} else {
+		logger.Info(message+".end", "message", message, "duration", duration)
+	}
+	return err
+}
+
+// runWithSpinnerEvent runs fn the same way runWithSpinner does, but logs a
+// single bare `message` event with its duration on completion instead of a
+// message.start/message.end pair. Use this for steps like backup.created
+// that are themselves the lifecycle event, rather than a long-running
+// phase with a distinct start and end.
+func runWithSpinnerEvent(logger *slog.Logger, message string, fn func() error) error {
+	start := time.Now()
+
+	if isTerminal(os.Stdout) {
+		sp := newSpinner(message)
+		err := fn()
+		sp.stop(err == nil)
+		return err
+	}
+
+	err := fn()
+	duration := time.Since(start)
+	if err != nil {
+		logger.Error(message, "duration", duration, "error", err)
+	} else {
+		logger.Info(message, "duration", duration)
+	}
+	return err
+}
+
+const spinnerInterval = 100 * time.Millisecond
+
+var spinnerFrames = [...]string{"|", "/", "-", "\\"}
+
+// spinner is a minimal terminal spinner for wrapping long-running steps
+// like CreateBackup and home-manager switch when stdout is interactive.
+type spinner struct {
+	message string
+	done    chan struct{}
+}
+
+func newSpinner(message string) *spinner {
+	s := &spinner{message: message, done: make(chan struct{})}
+
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+
+		i := 0
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				fmt.Fprintf(os.Stdout, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], message)
+				i++
+			}
+		}
+	}()
+
+	return s
+}
+
+func (s *spinner) stop(success bool) {
+	close(s.done)
+
+	status := "done"
+	if !success {
+		status = "failed"
+	}
+	fmt.Fprintf(os.Stdout, "\r\033[K%s %s\n", s.message, status)
+}
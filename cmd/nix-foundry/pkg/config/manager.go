@@ -2,10 +2,11 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -15,6 +16,23 @@ type Manager struct {
 	configDir string
 	backupDir string
 	paths     Paths
+
+	// encryption decrypts/encrypts `!encrypted` scalar nodes in config
+	// documents. nil when the user hasn't configured an `encryption:` block.
+	encryption EncryptionBackend
+
+	// encryptedPaths remembers, per absolute config path, which
+	// dot-joined mapping-key paths were decrypted by readConfigAt, so
+	// WriteConfig can re-encrypt those same fields instead of persisting
+	// them as plaintext.
+	encryptedPathsMu sync.Mutex
+	encryptedPaths   map[string][]string
+
+	subscribersMu sync.Mutex
+	subscribers   []chan ConfigEvent
+
+	generators *Generators
+	logger     *slog.Logger
 }
 
 // ConfigOptions represents options for configuration operations
@@ -33,8 +51,10 @@ func NewConfigManager() (*Manager, error) {
 	configDir := filepath.Join(home, ".config", "nix-foundry")
 
 	cm := &Manager{
-		configDir: configDir,
-		backupDir: filepath.Join(configDir, "backups"),
+		configDir:      configDir,
+		backupDir:      filepath.Join(configDir, "backups"),
+		logger:         NewLogger(false),
+		encryptedPaths: make(map[string][]string),
 		paths: Paths{
 			Personal: filepath.Join(configDir, "config.yaml"),
 			Project:  filepath.Join(configDir, "project.yaml"),
@@ -43,9 +63,46 @@ func NewConfigManager() (*Manager, error) {
 		},
 	}
 
+	if err := cm.loadEncryption(); err != nil {
+		return nil, fmt.Errorf("failed to load encryption config: %w", err)
+	}
+
+	generators, err := NewGenerators(filepath.Join(configDir, "templates"), cm.EncryptionTemplateFuncs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize nix generators: %w", err)
+	}
+	cm.generators = generators
+
 	return cm, nil
 }
 
+// loadEncryption reads the `encryption:` block out of the personal config,
+// ignoring every other field, so a backend is available before the rest of
+// the document (which may itself contain !encrypted nodes) is parsed.
+func (cm *Manager) loadEncryption() error {
+	data, err := os.ReadFile(cm.paths.Personal)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	var cfg struct {
+		Encryption EncryptionConfig `yaml:"encryption"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid configuration format: %w", err)
+	}
+
+	backend, err := newEncryptionBackend(cfg.Encryption)
+	if err != nil {
+		return fmt.Errorf("failed to initialize encryption backend: %w", err)
+	}
+	cm.encryption = backend
+
+	return nil
+}
+
 // SafeWrite writes configuration with optional backup and validation
 func (cm *Manager) SafeWrite(filename string, config interface{}, opts Options) error {
 	if opts.Backup {
@@ -65,14 +122,47 @@ func (cm *Manager) SafeWrite(filename string, config interface{}, opts Options)
 	return cm.WriteConfig(filename, config)
 }
 
-// ReadConfig reads and unmarshals configuration
+// ReadConfig reads and unmarshals configuration, transparently decrypting
+// any `!encrypted` scalar nodes using the Manager's configured backend.
 func (cm *Manager) ReadConfig(filename string, config interface{}) error {
-	configPath := filepath.Join(cm.configDir, filename)
+	return cm.readConfigAt(filepath.Join(cm.configDir, filename), config)
+}
+
+// readConfigAt is ReadConfig for an already-resolved absolute path, used
+// where the caller already holds one (e.g. a file in a synced remote team
+// source's cache under configDir/remote, or a file Watch reloads).
+func (cm *Manager) readConfigAt(configPath string, config interface{}) error {
+	start := time.Now()
+	defer func() {
+		cm.logger.Debug("config.read", "path", configPath, "duration", time.Since(start))
+	}()
+
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to read configuration: %w", err)
 	}
 
+	if cm.encryption != nil {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("invalid configuration format: %w", err)
+		}
+
+		encrypted, err := decryptTree(&doc, cm.encryption)
+		if err != nil {
+			return err
+		}
+		cm.encryptedPathsMu.Lock()
+		cm.encryptedPaths[configPath] = encrypted
+		cm.encryptedPathsMu.Unlock()
+
+		if err := doc.Decode(config); err != nil {
+			return fmt.Errorf("invalid configuration format: %w", err)
+		}
+
+		return nil
+	}
+
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return fmt.Errorf("invalid configuration format: %w", err)
 	}
@@ -84,7 +174,12 @@ func (cm *Manager) ReadConfig(filename string, config interface{}) error {
 func (cm *Manager) WriteConfig(filename string, config interface{}) error {
 	configPath := filepath.Join(cm.configDir, filename)
 
-	data, err := yaml.Marshal(config)
+	start := time.Now()
+	defer func() {
+		cm.logger.Debug("config.written", "path", configPath, "duration", time.Since(start))
+	}()
+
+	data, err := cm.marshalConfig(configPath, config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal configuration: %w", err)
 	}
@@ -100,21 +195,38 @@ func (cm *Manager) WriteConfig(filename string, config interface{}) error {
 	return nil
 }
 
-// CreateBackup creates a timestamped backup of the current configuration
-func (cm *Manager) CreateBackup() error {
-	if err := os.MkdirAll(cm.backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+// marshalConfig marshals config to YAML, re-encrypting any field at
+// configPath that readConfigAt previously decrypted so a round-trip
+// through ReadConfig/WriteConfig never downgrades a `!encrypted` value to
+// plaintext on disk.
+func (cm *Manager) marshalConfig(configPath string, config interface{}) ([]byte, error) {
+	if cm.encryption == nil {
+		return yaml.Marshal(config)
 	}
 
-	timestamp := time.Now().Format("20060102-150405")
-	backupPath := filepath.Join(cm.backupDir, fmt.Sprintf("backup-%s.tar.gz", timestamp))
+	cm.encryptedPathsMu.Lock()
+	paths := cm.encryptedPaths[configPath]
+	cm.encryptedPathsMu.Unlock()
 
-	cmd := exec.Command("tar", "-czf", backupPath, "-C", cm.configDir, ".")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create backup archive: %w", err)
+	if len(paths) == 0 {
+		return yaml.Marshal(config)
 	}
 
-	return nil
+	encryptedPaths := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		encryptedPaths[p] = true
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return nil, err
+	}
+
+	if err := encryptTree(&doc, cm.encryption, encryptedPaths); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(&doc)
 }
 
 func (cm *Manager) ConfigExists(filename string) bool {
@@ -149,20 +261,26 @@ func (cm *Manager) Apply(config interface{}) error {
 			return fmt.Errorf("validation failed: %w", err)
 		}
 	}
+	cm.logger.Info("config.validated")
 
 	// Create backup before applying
-	if err := cm.CreateBackup(); err != nil {
+	if err := runWithSpinnerEvent(cm.logger, "backup.created", cm.CreateBackup); err != nil {
 		return fmt.Errorf("backup failed: %w", err)
 	}
 
 	// Generate Nix configuration
+	start := time.Now()
 	if err := cm.generateNixConfig(config); err != nil {
 		return fmt.Errorf("failed to generate nix config: %w", err)
 	}
+	cm.logger.Info("nix.generated", "duration", time.Since(start))
 
 	// Apply using home-manager
-	cmd := exec.Command("home-manager", "switch")
-	if err := cmd.Run(); err != nil {
+	err := runWithSpinner(cm.logger, "home-manager.switch", func() error {
+		cmd := exec.Command("home-manager", "switch")
+		return cmd.Run()
+	})
+	if err != nil {
 		return fmt.Errorf("failed to apply configuration: %w", err)
 	}
 
@@ -232,73 +350,49 @@ func (cm *Manager) mergeLists(a, b []string) []string {
 	return result
 }
 
+// generateNixConfig renders the Nix generator registered for config's
+// platform and writes every file it produces under configDir/home-manager.
 func (cm *Manager) generateNixConfig(config interface{}) error {
-	// Convert config to NixConfig type
 	nixConfig, ok := config.(*NixConfig)
 	if !ok {
 		return fmt.Errorf("invalid configuration type: expected *NixConfig")
 	}
 
-	// Generate home-manager configuration
-	configPath := filepath.Join(cm.configDir, "home-manager", "home.nix")
+	platform := nixConfig.Platform
+	if platform == "" {
+		platform = "home-manager"
+	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-		return fmt.Errorf("failed to create home-manager directory: %w", err)
+	generator, err := cm.generators.Get(platform)
+	if err != nil {
+		return err
 	}
 
-	// Generate Nix expression
-	nixExpr := generateHomeManagerConfig(nixConfig)
+	files, err := generator.Generate(nixConfig)
+	if err != nil {
+		return fmt.Errorf("failed to generate nix config: %w", err)
+	}
+
+	outDir := filepath.Join(cm.configDir, "home-manager")
+	for relPath, content := range files {
+		outPath := filepath.Join(outDir, relPath)
 
-	// Write configuration
-	if err := os.WriteFile(configPath, []byte(nixExpr), 0644); err != nil {
-		return fmt.Errorf("failed to write home-manager configuration: %w", err)
+		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+			return fmt.Errorf("failed to create home-manager directory: %w", err)
+		}
+
+		if err := os.WriteFile(outPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", relPath, err)
+		}
 	}
 
 	return nil
 }
 
-func generateHomeManagerConfig(config *NixConfig) string {
-	// Basic home-manager configuration template
-	return fmt.Sprintf(`
-{ config, pkgs, ... }:
-
-{
-  home.username = builtins.getEnv "USER";
-  home.homeDirectory = builtins.getEnv "HOME";
-  home.stateVersion = "23.11";
-
-  programs.home-manager.enable = true;
-
-  # Shell configuration
-  programs.%s.enable = true;
-
-  # Editor configuration
-  programs.%s.enable = true;
-
-  # Git configuration
-  programs.git = {
-    enable = %v;
-    userName = "%s";
-    userEmail = "%s";
-  };
-
-  # Package management
-  home.packages = with pkgs; [
-    %s
-  ];
-}`,
-		config.Shell.Type,
-		config.Editor.Type,
-		config.Git.Enable,
-		config.Git.User.Name,
-		config.Git.User.Email,
-		strings.Join(config.Packages.Additional, "\n    "),
-	)
-}
-
 // LoadConfig loads any configuration type with proper validation
 func (cm *Manager) LoadConfig(configType Type, name string) (interface{}, error) {
+	cm.logger.Debug("config.load", "config_type", configType, "name", name)
+
 	var config interface{}
 	var path string
 
@@ -322,6 +416,23 @@ func (cm *Manager) LoadConfig(configType Type, name string) (interface{}, error)
 				Type: TeamConfigType,
 			},
 		}
+
+		src, err := cm.teamSource()
+		if err != nil {
+			return nil, err
+		}
+		if src.enabled() {
+			cacheDir, err := cm.syncRemoteSource(src)
+			if err != nil {
+				return nil, err
+			}
+			absPath := filepath.Join(cacheDir, src.Path, name+".yaml")
+			if err := cm.readConfigAt(absPath, config); err != nil {
+				return nil, err
+			}
+			return config, nil
+		}
+
 		path = filepath.Join(cm.paths.Team, name+".yaml")
 	default:
 		return nil, fmt.Errorf("unknown config type: %s", configType)
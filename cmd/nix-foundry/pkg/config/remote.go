@@ -0,0 +1,213 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// RemoteSourceConfig is the `team.source` block of the personal config,
+// pointing LoadConfig(TeamConfigType, ...) at a git repository instead of
+// paths.Team.
+type RemoteSourceConfig struct {
+	URL  string           `yaml:"url"`
+	Ref  string           `yaml:"ref,omitempty"`
+	Path string           `yaml:"path,omitempty"`
+	Auth RemoteAuthConfig `yaml:"auth,omitempty"`
+}
+
+func (r RemoteSourceConfig) enabled() bool {
+	return r.URL != ""
+}
+
+// RemoteAuthConfig configures SSH auth for a RemoteSourceConfig, modeled
+// after config-mapper's SSH support. SSHKeyPassphrase and Password name
+// environment variables to read the actual secret from, so credentials
+// never need to live in the config file itself.
+type RemoteAuthConfig struct {
+	SSHKey           string `yaml:"sshKey,omitempty"`
+	SSHKeyPassphrase string `yaml:"sshKeyPassphrase,omitempty"`
+	User             string `yaml:"user,omitempty"`
+	Password         string `yaml:"password,omitempty"`
+}
+
+func (a RemoteAuthConfig) authMethod() (transport.AuthMethod, error) {
+	switch {
+	case a.SSHKey != "":
+		user := a.User
+		if user == "" {
+			user = "git"
+		}
+		return gitssh.NewPublicKeysFromFile(user, a.SSHKey, os.Getenv(a.SSHKeyPassphrase))
+	case a.Password != "":
+		user := a.User
+		if user == "" {
+			user = "git"
+		}
+		return &githttp.BasicAuth{Username: user, Password: os.Getenv(a.Password)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// remoteCacheDir returns the directory RemoteSourceConfig is cloned/fetched
+// into, keyed by a hash of the fields that determine its contents.
+func (cm *Manager) remoteCacheDir(src RemoteSourceConfig) string {
+	h := sha256.Sum256([]byte(src.URL + "|" + src.Ref + "|" + src.Path))
+	return filepath.Join(cm.configDir, "remote", hex.EncodeToString(h[:])[:16])
+}
+
+// syncRemoteSource clones src into its cache directory if absent, or
+// fetches and checks out the requested ref otherwise.
+func (cm *Manager) syncRemoteSource(src RemoteSourceConfig) (string, error) {
+	if !src.enabled() {
+		return "", fmt.Errorf("remote source has no url configured")
+	}
+
+	auth, err := src.Auth.authMethod()
+	if err != nil {
+		return "", fmt.Errorf("failed to load remote source credentials: %w", err)
+	}
+
+	cacheDir := cm.remoteCacheDir(src)
+
+	repo, err := git.PlainOpen(cacheDir)
+	if err != nil {
+		if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create remote cache directory: %w", err)
+		}
+
+		repo, err = git.PlainClone(cacheDir, false, &git.CloneOptions{
+			URL:  src.URL,
+			Auth: auth,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to clone %s: %w", src.URL, err)
+		}
+	} else {
+		wt, err := repo.Worktree()
+		if err != nil {
+			return "", fmt.Errorf("failed to open worktree for %s: %w", src.URL, err)
+		}
+		if err := wt.Pull(&git.PullOptions{Auth: auth}); err != nil && err != git.NoErrAlreadyUpToDate {
+			return "", fmt.Errorf("failed to fetch %s: %w", src.URL, err)
+		}
+	}
+
+	if src.Ref != "" {
+		wt, err := repo.Worktree()
+		if err != nil {
+			return "", fmt.Errorf("failed to open worktree for %s: %w", src.URL, err)
+		}
+		if err := checkoutRef(repo, wt, src.Ref); err != nil {
+			return "", fmt.Errorf("failed to checkout %s at %s: %w", src.URL, src.Ref, err)
+		}
+	}
+
+	return cacheDir, nil
+}
+
+// checkoutRef resolves ref the way git itself would for a short name:
+// a fully-qualified ref as-is, then a local branch, then a branch that
+// only exists as origin's remote-tracking ref (the common case right
+// after a fresh clone, since PlainClone only creates a local branch for
+// the remote's HEAD), then a tag, and finally as a literal commit hash.
+func checkoutRef(repo *git.Repository, wt *git.Worktree, ref string) error {
+	candidates := []plumbing.ReferenceName{
+		plumbing.ReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref),
+	}
+	for _, name := range candidates {
+		if _, err := repo.Reference(name, true); err != nil {
+			continue
+		}
+		return wt.Checkout(&git.CheckoutOptions{Branch: name})
+	}
+
+	if remote, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", ref), true); err == nil {
+		return wt.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(ref),
+			Hash:   remote.Hash(),
+			Create: true,
+		})
+	}
+
+	if _, err := repo.Reference(plumbing.NewTagReferenceName(ref), true); err == nil {
+		return wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewTagReferenceName(ref)})
+	}
+
+	hash := plumbing.NewHash(ref)
+	if hash.IsZero() {
+		return fmt.Errorf("%q is not a branch, tag, or commit hash", ref)
+	}
+	return wt.Checkout(&git.CheckoutOptions{Hash: hash})
+}
+
+// teamSource reads the `team.source` block out of the personal config, if
+// any.
+func (cm *Manager) teamSource() (RemoteSourceConfig, error) {
+	data, err := os.ReadFile(cm.paths.Personal)
+	if os.IsNotExist(err) {
+		return RemoteSourceConfig{}, nil
+	} else if err != nil {
+		return RemoteSourceConfig{}, fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	var cfg struct {
+		Team TeamSettings `yaml:"team"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return RemoteSourceConfig{}, fmt.Errorf("invalid configuration format: %w", err)
+	}
+
+	return cfg.Team.Source, nil
+}
+
+// SyncTeamSources refreshes every configured remote team source. No
+// `nix-foundry team sync` command exists in this repository to call it.
+func (cm *Manager) SyncTeamSources() error {
+	src, err := cm.teamSource()
+	if err != nil {
+		return err
+	}
+	if !src.enabled() {
+		return nil
+	}
+
+	if _, err := cm.syncRemoteSource(src); err != nil {
+		return fmt.Errorf("failed to sync team source: %w", err)
+	}
+
+	return nil
+}
+
+// LoadAndValidateTeamConfig loads the named team config (transparently
+// resolving a remote team.source if one is configured) and validates it
+// against the local personal config before the caller applies it.
+func (cm *Manager) LoadAndValidateTeamConfig(name string) (*ProjectConfig, error) {
+	team, err := cm.LoadConfig(TeamConfigType, name)
+	if err != nil {
+		return nil, err
+	}
+	teamConfig := team.(*ProjectConfig)
+
+	personal, err := cm.LoadConfig(PersonalConfigType, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load personal config: %w", err)
+	}
+
+	if err := NewValidator(personal.(*NixConfig)).ValidateTeamConflicts(teamConfig); err != nil {
+		return nil, fmt.Errorf("team config conflicts with personal config: %w", err)
+	}
+
+	return teamConfig, nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newTestRepo creates a local git repository whose default branch and a
+// "feature" branch each write a distinguishing marker file, so tests
+// can assert syncRemoteSource checked out the right one.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("git.PlainInit() error = %v", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree() error = %v", err)
+	}
+
+	commit := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, "marker.txt"), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if _, err := wt.Add("marker.txt"); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		if _, err := wt.Commit("marker: "+name, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com"},
+		}); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+
+	commit("main", "main")
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feature"),
+		Create: true,
+		Hash:   head.Hash(),
+	}); err != nil {
+		t.Fatalf("Checkout(feature) error = %v", err)
+	}
+	commit("feature", "feature")
+
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: head.Name()}); err != nil {
+		t.Fatalf("Checkout(main) error = %v", err)
+	}
+
+	return dir
+}
+
+func TestSyncRemoteSourceChecksOutPlainBranchName(t *testing.T) {
+	repoDir := newTestRepo(t)
+
+	cm := &Manager{configDir: t.TempDir(), logger: NewLogger(false)}
+	src := RemoteSourceConfig{URL: repoDir, Ref: "feature"}
+
+	cacheDir, err := cm.syncRemoteSource(src)
+	if err != nil {
+		t.Fatalf("syncRemoteSource() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(cacheDir, "marker.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile(marker.txt) error = %v", err)
+	}
+	if string(got) != "feature" {
+		t.Fatalf("marker.txt = %q, want %q (checked out wrong ref for plain branch name)", got, "feature")
+	}
+}
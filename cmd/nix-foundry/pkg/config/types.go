@@ -0,0 +1,83 @@
+package config
+
+// Type identifies the kind of configuration a file on disk represents.
+type Type string
+
+const (
+	PersonalConfigType Type = "personal"
+	ProjectConfigType  Type = "project"
+	TeamConfigType     Type = "team"
+)
+
+// Paths holds the on-disk locations the Manager reads and writes.
+type Paths struct {
+	Personal string
+	Project  string
+	Team     string
+	Current  string
+}
+
+// BaseConfig carries the fields shared by every configuration document.
+type BaseConfig struct {
+	Type    Type   `yaml:"type"`
+	Version string `yaml:"version,omitempty"`
+}
+
+// NixConfig is the personal, machine-level configuration.
+type NixConfig struct {
+	Version  string         `yaml:"version"`
+	Platform string         `yaml:"platform,omitempty"`
+	Shell    ShellConfig    `yaml:"shell"`
+	Editor   EditorConfig   `yaml:"editor"`
+	Git      GitConfig      `yaml:"git"`
+	Packages PackagesConfig `yaml:"packages"`
+	Team     TeamSettings   `yaml:"team,omitempty"`
+	// Encryption configures the backend used to decrypt `!encrypted` scalar
+	// nodes encountered by ReadConfig and to encrypt values written back out.
+	Encryption EncryptionConfig `yaml:"encryption,omitempty"`
+}
+
+// Validate satisfies the `interface{ Validate() error }` contract that
+// SafeWrite and Apply type-assert against.
+func (c *NixConfig) Validate() error {
+	return NewValidator(c).ValidateConfig()
+}
+
+type ShellConfig struct {
+	Type string `yaml:"type"`
+}
+
+type EditorConfig struct {
+	Type string `yaml:"type"`
+}
+
+type GitConfig struct {
+	Enable bool `yaml:"enable"`
+	User   struct {
+		Name  string `yaml:"name"`
+		Email string `yaml:"email"`
+	} `yaml:"user"`
+}
+
+type PackagesConfig struct {
+	Additional []string `yaml:"additional,omitempty"`
+}
+
+type TeamSettings struct {
+	Settings map[string]string  `yaml:"settings,omitempty"`
+	Source   RemoteSourceConfig `yaml:"source,omitempty"`
+}
+
+// ProjectConfig is shared by both project-scoped and team-scoped documents.
+type ProjectConfig struct {
+	BaseConfig  `yaml:",inline"`
+	Required    []string          `yaml:"required,omitempty"`
+	Tools       ToolsConfig       `yaml:"tools,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+}
+
+type ToolsConfig struct {
+	Go     []string `yaml:"go,omitempty"`
+	Node   []string `yaml:"node,omitempty"`
+	Python []string `yaml:"python,omitempty"`
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 )
 
 var (
@@ -12,13 +14,28 @@ var (
 
 type Validator struct {
 	config *NixConfig
+	logger *slog.Logger
 }
 
 func NewValidator(config *NixConfig) *Validator {
-	return &Validator{config: config}
+	return &Validator{config: config, logger: NewLogger(false)}
 }
 
 func (v *Validator) ValidateConfig() error {
+	start := time.Now()
+
+	err := v.validateConfig()
+
+	if err != nil {
+		v.logger.Error("config.validated", "config_type", PersonalConfigType, "duration", time.Since(start), "error", err)
+	} else {
+		v.logger.Debug("config.validated", "config_type", PersonalConfigType, "duration", time.Since(start))
+	}
+
+	return err
+}
+
+func (v *Validator) validateConfig() error {
 	if v.config.Version == "" {
 		return fmt.Errorf("version is required")
 	}
@@ -39,6 +56,11 @@ func (v *Validator) ValidateConfig() error {
 }
 
 func (v *Validator) ValidateConflicts(other *NixConfig) error {
+	start := time.Now()
+	defer func() {
+		v.logger.Debug("config.conflicts_checked", "duration", time.Since(start))
+	}()
+
 	var conflicts []string
 
 	// Check shell conflicts
@@ -69,6 +91,34 @@ func (v *Validator) ValidateConflicts(other *NixConfig) error {
 	return nil
 }
 
+// ValidateTeamConflicts checks a ProjectConfig's Environment against the
+// personal config's Team.Settings, reporting any key present in both with
+// different values. Unlike ValidateConflicts (which compares two full
+// NixConfig documents), it only compares the fields team configs actually
+// have.
+func (v *Validator) ValidateTeamConflicts(team *ProjectConfig) error {
+	start := time.Now()
+	defer func() {
+		v.logger.Debug("config.team_conflicts_checked", "duration", time.Since(start))
+	}()
+
+	var conflicts []string
+
+	for env, value := range team.Environment {
+		if personalValue, exists := v.config.Team.Settings[env]; exists {
+			if value != personalValue {
+				conflicts = append(conflicts, fmt.Sprintf("environment %s has conflicting values", env))
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("- %s", strings.Join(conflicts, "\n- "))
+	}
+
+	return nil
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if strings.EqualFold(s, item) {
@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+func TestValidateConflicts(t *testing.T) {
+	personal := &NixConfig{
+		Shell:  ShellConfig{Type: "zsh"},
+		Editor: EditorConfig{Type: "vim"},
+		Team:   TeamSettings{Settings: map[string]string{"AWS_REGION": "us-east-1"}},
+	}
+
+	t.Run("no conflicts", func(t *testing.T) {
+		other := &NixConfig{
+			Shell:  ShellConfig{Type: "zsh"},
+			Editor: EditorConfig{Type: "vim"},
+			Team:   TeamSettings{Settings: map[string]string{"AWS_REGION": "us-east-1"}},
+		}
+		if err := NewValidator(personal).ValidateConflicts(other); err != nil {
+			t.Fatalf("ValidateConflicts() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("shell, editor, and environment conflicts", func(t *testing.T) {
+		other := &NixConfig{
+			Shell:  ShellConfig{Type: "fish"},
+			Editor: EditorConfig{Type: "nvim"},
+			Team:   TeamSettings{Settings: map[string]string{"AWS_REGION": "eu-west-1"}},
+		}
+		err := NewValidator(personal).ValidateConflicts(other)
+		if err == nil {
+			t.Fatal("ValidateConflicts() error = nil, want conflicts reported")
+		}
+	})
+}
+
+func TestValidateTeamConflicts(t *testing.T) {
+	personal := &NixConfig{
+		Shell:  ShellConfig{Type: "zsh"},
+		Editor: EditorConfig{Type: "vim"},
+		Team:   TeamSettings{Settings: map[string]string{"AWS_REGION": "us-east-1"}},
+	}
+
+	t.Run("no conflicts", func(t *testing.T) {
+		team := &ProjectConfig{Environment: map[string]string{"AWS_REGION": "us-east-1"}}
+		if err := NewValidator(personal).ValidateTeamConflicts(team); err != nil {
+			t.Fatalf("ValidateTeamConflicts() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("conflicting environment value", func(t *testing.T) {
+		team := &ProjectConfig{Environment: map[string]string{"AWS_REGION": "eu-west-1"}}
+		if err := NewValidator(personal).ValidateTeamConflicts(team); err == nil {
+			t.Fatal("ValidateTeamConflicts() error = nil, want a conflict reported")
+		}
+	})
+
+	t.Run("team-only keys never conflict", func(t *testing.T) {
+		team := &ProjectConfig{Environment: map[string]string{"CI": "true"}}
+		if err := NewValidator(personal).ValidateTeamConflicts(team); err != nil {
+			t.Fatalf("ValidateTeamConflicts() error = %v, want nil", err)
+		}
+	})
+}
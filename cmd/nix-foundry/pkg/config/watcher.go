@@ -0,0 +1,268 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces bursts of writes (editors often save via a
+// temp-file-then-rename dance that fires several fsnotify events) into a
+// single re-apply.
+const reloadDebounce = 500 * time.Millisecond
+
+// ConfigEventType identifies what happened during a Watch cycle.
+type ConfigEventType string
+
+const (
+	ConfigEventReloaded   ConfigEventType = "reloaded"
+	ConfigEventRolledBack ConfigEventType = "rolled_back"
+	ConfigEventError      ConfigEventType = "error"
+)
+
+// ConfigEvent is published to every Subscribe channel whenever Watch
+// reacts to a change on disk.
+type ConfigEvent struct {
+	Type ConfigEventType
+	Path string
+	Err  error
+}
+
+// Watch watches paths.Personal, paths.Project, and every file under
+// paths.Team for changes (and reloads on SIGHUP), debouncing rapid writes
+// and re-running Apply(config) on each reload. A reload that fails to
+// apply is rolled back to the newest CreateBackup archive that existed
+// before that reload started, so a bad edit never leaves home-manager switch half-applied. Watch blocks
+// until ctx is canceled.
+func (cm *Manager) Watch(ctx context.Context, config interface{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, path := range cm.watchPaths() {
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var (
+		debounce *time.Timer
+		pending  string
+	)
+	reload := make(chan string, 1)
+
+	defer cm.closeSubscribers()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			pending = event.Name
+			if debounce == nil {
+				debounce = time.AfterFunc(reloadDebounce, func() { reload <- pending })
+			} else {
+				debounce.Reset(reloadDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			cm.publish(ConfigEvent{Type: ConfigEventError, Err: err})
+
+		case <-hup:
+			cm.reload(config, cm.paths.Personal)
+
+		case path := <-reload:
+			debounce = nil
+			cm.reload(config, path)
+		}
+	}
+}
+
+// watchPaths returns every path Watch should register with fsnotify:
+// paths.Personal, paths.Project, and every file currently under
+// paths.Team.
+func (cm *Manager) watchPaths() []string {
+	paths := []string{cm.paths.Personal, cm.paths.Project}
+
+	entries, err := os.ReadDir(cm.paths.Team)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			paths = append(paths, filepath.Join(cm.paths.Team, entry.Name()))
+		}
+	}
+
+	return paths
+}
+
+// reload re-reads whichever document changed at path and re-applies the
+// personal config, rolling back to the last known-good backup if the
+// read, merge, or apply fails. personal must be the *NixConfig Watch was
+// started with: a change to paths.Personal is decoded directly into it;
+// a change to paths.Project or a file under paths.Team is decoded into a
+// separate ProjectConfig, merged with MergeProjectConfigs, and folded
+// into personal.Team.Settings, since those documents don't share
+// NixConfig's shape and must never be decoded onto it directly.
+func (cm *Manager) reload(personal interface{}, path string) {
+	// Apply backs up configDir, edit included, before it generates
+	// anything, so the known-good snapshot is the newest backup that
+	// already existed when this reload started.
+	knownGood, listErr := cm.ListBackups()
+
+	var err error
+	if path == cm.paths.Personal {
+		err = cm.readConfigAt(path, personal)
+	} else {
+		err = cm.reloadProjectEnvironment(personal, path)
+	}
+
+	if err == nil {
+		err = cm.Apply(personal)
+	}
+
+	if err != nil {
+		restoreErr := listErr
+		if restoreErr != nil {
+			restoreErr = fmt.Errorf("failed to list backups: %w", restoreErr)
+		} else {
+			restoreErr = cm.restoreLatestBackup(knownGood)
+		}
+		if restoreErr != nil {
+			cm.publish(ConfigEvent{Type: ConfigEventError, Path: path, Err: fmt.Errorf("reload failed and rollback failed: %w (original error: %v)", restoreErr, err)})
+			return
+		}
+		cm.publish(ConfigEvent{Type: ConfigEventRolledBack, Path: path, Err: err})
+		return
+	}
+
+	cm.publish(ConfigEvent{Type: ConfigEventReloaded, Path: path})
+}
+
+// reloadProjectEnvironment re-reads paths.Project and every file under
+// paths.Team, merges them with MergeProjectConfigs, validates the result
+// against personal, and folds its Environment into personal.Team.Settings.
+// It never decodes a ProjectConfig document onto personal directly, since
+// personal is a *NixConfig and has no Required/Tools/Environment fields to
+// receive it.
+func (cm *Manager) reloadProjectEnvironment(personal interface{}, path string) error {
+	nixConfig, ok := personal.(*NixConfig)
+	if !ok {
+		return fmt.Errorf("cannot merge project config into %T: expected *NixConfig", personal)
+	}
+
+	var merged ProjectConfig
+	if _, err := os.Stat(cm.paths.Project); err == nil {
+		if err := cm.readConfigAt(cm.paths.Project, &merged); err != nil {
+			return fmt.Errorf("failed to read %s: %w", cm.paths.Project, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat %s: %w", cm.paths.Project, err)
+	}
+
+	entries, err := os.ReadDir(cm.paths.Team)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to list %s: %w", cm.paths.Team, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		var team ProjectConfig
+		teamPath := filepath.Join(cm.paths.Team, entry.Name())
+		if err := cm.readConfigAt(teamPath, &team); err != nil {
+			return fmt.Errorf("failed to read %s: %w", teamPath, err)
+		}
+		merged = cm.MergeProjectConfigs(merged, team)
+	}
+
+	if err := NewValidator(nixConfig).ValidateTeamConflicts(&merged); err != nil {
+		return fmt.Errorf("project config conflicts with personal config: %w", err)
+	}
+
+	if nixConfig.Team.Settings == nil {
+		nixConfig.Team.Settings = make(map[string]string, len(merged.Environment))
+	}
+	for k, v := range merged.Environment {
+		nixConfig.Team.Settings[k] = v
+	}
+
+	return nil
+}
+
+// restoreLatestBackup extracts the newest of backups (sorted newest-first,
+// as ListBackups returns them) over configDir.
+func (cm *Manager) restoreLatestBackup(backups []BackupEntry) error {
+	if len(backups) == 0 {
+		return fmt.Errorf("no backups available to roll back to")
+	}
+
+	latest := backups[0]
+	if err := extractTarGz(filepath.Join(cm.backupDir, latest.Path), cm.configDir); err != nil {
+		return fmt.Errorf("failed to extract backup %s: %w", latest.ID, err)
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel of ConfigEvents published by Watch, so other
+// subsystems can react to reloads without polling. The channel is buffered
+// and closed when ctx passed to Watch is canceled.
+func (cm *Manager) Subscribe() <-chan ConfigEvent {
+	cm.subscribersMu.Lock()
+	defer cm.subscribersMu.Unlock()
+
+	ch := make(chan ConfigEvent, 8)
+	cm.subscribers = append(cm.subscribers, ch)
+	return ch
+}
+
+// closeSubscribers closes every channel handed out by Subscribe and clears
+// the list, so subscribers ranging over the channel see it close rather
+// than blocking forever once Watch returns.
+func (cm *Manager) closeSubscribers() {
+	cm.subscribersMu.Lock()
+	defer cm.subscribersMu.Unlock()
+
+	for _, ch := range cm.subscribers {
+		close(ch)
+	}
+	cm.subscribers = nil
+}
+
+func (cm *Manager) publish(event ConfigEvent) {
+	cm.subscribersMu.Lock()
+	defer cm.subscribersMu.Unlock()
+
+	for _, ch := range cm.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber; drop rather than block the watch loop.
+		}
+	}
+}
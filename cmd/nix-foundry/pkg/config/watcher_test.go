@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestWatchManager(t *testing.T) *Manager {
+	t.Helper()
+
+	dir := t.TempDir()
+	teamDir := filepath.Join(dir, "teams")
+	if err := os.MkdirAll(teamDir, 0755); err != nil {
+		t.Fatalf("MkdirAll(teams) error = %v", err)
+	}
+
+	return &Manager{
+		configDir:      dir,
+		encryptedPaths: make(map[string][]string),
+		logger:         NewLogger(false),
+		paths: Paths{
+			Personal: filepath.Join(dir, "config.yaml"),
+			Project:  filepath.Join(dir, "project.yaml"),
+			Team:     teamDir,
+		},
+	}
+}
+
+func writeYAML(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile(%s) error = %v", path, err)
+	}
+}
+
+func TestReloadProjectEnvironmentDoesNotClobberPersonalConfig(t *testing.T) {
+	cm := newTestWatchManager(t)
+
+	writeYAML(t, cm.paths.Project, "type: project\nversion: should-not-leak\nrequired:\n  - git\nenvironment:\n  AWS_REGION: us-east-1\n")
+	writeYAML(t, filepath.Join(cm.paths.Team, "infra.yaml"), "type: team\nenvironment:\n  CI: \"true\"\n")
+
+	personal := &NixConfig{
+		Version: "1",
+		Shell:   ShellConfig{Type: "zsh"},
+		Editor:  EditorConfig{Type: "vim"},
+	}
+
+	if err := cm.reloadProjectEnvironment(personal, cm.paths.Project); err != nil {
+		t.Fatalf("reloadProjectEnvironment() error = %v", err)
+	}
+
+	if personal.Version != "1" {
+		t.Fatalf("personal.Version = %q, want %q (project config must not overwrite unrelated fields)", personal.Version, "1")
+	}
+	if got := personal.Team.Settings["AWS_REGION"]; got != "us-east-1" {
+		t.Fatalf("personal.Team.Settings[AWS_REGION] = %q, want %q", got, "us-east-1")
+	}
+	if got := personal.Team.Settings["CI"]; got != "true" {
+		t.Fatalf("personal.Team.Settings[CI] = %q, want %q", got, "true")
+	}
+}
+
+func TestReloadProjectEnvironmentRejectsConflictingEnvironment(t *testing.T) {
+	cm := newTestWatchManager(t)
+
+	writeYAML(t, cm.paths.Project, "type: project\nenvironment:\n  AWS_REGION: eu-west-1\n")
+
+	personal := &NixConfig{
+		Version: "1",
+		Team:    TeamSettings{Settings: map[string]string{"AWS_REGION": "us-east-1"}},
+	}
+
+	if err := cm.reloadProjectEnvironment(personal, cm.paths.Project); err == nil {
+		t.Fatal("reloadProjectEnvironment() error = nil, want a conflict error")
+	}
+}
+
+func TestReloadProjectEnvironmentRejectsNonNixConfig(t *testing.T) {
+	cm := newTestWatchManager(t)
+
+	if err := cm.reloadProjectEnvironment(&ProjectConfig{}, cm.paths.Project); err == nil {
+		t.Fatal("reloadProjectEnvironment() error = nil, want an error for a non-*NixConfig target")
+	}
+}